@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// StationConfig describes a single MQTT topic that reports a station's
+// activation state, and how to read that state out of its payload.
+type StationConfig struct {
+	Topic     string `json:"topic"`
+	StatePath string `json:"statePath"`
+}
+
+// PumpConfig describes one physical pump: where to send on/off commands
+// and which stations, if any are active, should keep it running.
+type PumpConfig struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	CommandTopic string          `json:"commandTopic"`
+	QoS          byte            `json:"qos"`
+	Retain       bool            `json:"retain"`
+	OnPayload    string          `json:"onPayload"`
+	OffPayload   string          `json:"offPayload"`
+	IdleTimeout  time.Duration   `json:"idleTimeout"`
+	Stations     []StationConfig `json:"stations"`
+
+	// StateTopic and AvailabilityTopic, when set, are published to
+	// (retained) so external observers can see the pump's state without
+	// snooping the relay topic directly. AvailabilityTopic is also tied to
+	// mqtt.lastWill (see deriveLastWill) when it can be, so it flips to
+	// offline on an unclean disconnect and not just a clean shutdown.
+	StateTopic        string `json:"stateTopic"`
+	AvailabilityTopic string `json:"availabilityTopic"`
+
+	// Discovery enables publishing a Home Assistant MQTT discovery config
+	// for this pump under DiscoveryPrefix on connect.
+	Discovery       bool   `json:"discovery"`
+	DiscoveryPrefix string `json:"discoveryPrefix"`
+
+	Safety SafetyConfig `json:"safety"`
+}
+
+// TimeWindow is an allowed time-of-day range in "HH:MM" 24h format. A
+// window where End is earlier than Start is treated as wrapping midnight.
+type TimeWindow struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// SafetyConfig guards a pump against running unattended for too long or
+// chattering relay switches.
+type SafetyConfig struct {
+	// MaxRunTime, if set, forces the pump off after it has run
+	// continuously for this long, even if stations are still active.
+	MaxRunTime time.Duration `json:"maxRunTime"`
+
+	// MinOffTime, if set, is the minimum time the pump must stay off
+	// before it is allowed to switch on again. Required when MaxRunTime is
+	// set (see validateSafetyConfig), since otherwise the max-run cutoff
+	// would be undone on the very next tick.
+	MinOffTime time.Duration `json:"minOffTime"`
+
+	// AllowedWindows, if non-empty, restricts switching the pump on to
+	// these time-of-day windows.
+	AllowedWindows []TimeWindow `json:"allowedWindows"`
+}
+
+// TLSConfig configures certificate-based TLS for an `ssl://` broker.
+type TLSConfig struct {
+	CAFile             string `json:"caFile"`
+	CertFile           string `json:"certFile"`
+	KeyFile            string `json:"keyFile"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+}
+
+// LastWillConfig is the message the broker publishes on our behalf if we
+// disconnect without a clean shutdown.
+type LastWillConfig struct {
+	Topic   string `json:"topic"`
+	Payload string `json:"payload"`
+	QoS     byte   `json:"qos"`
+	Retain  bool   `json:"retain"`
+}
+
+// MQTTConfig holds everything needed to dial and authenticate against the
+// broker.
+type MQTTConfig struct {
+	Broker       string          `json:"broker"`
+	ClientID     string          `json:"clientId"`
+	Username     string          `json:"username"`
+	Password     string          `json:"password"`
+	TLS          *TLSConfig      `json:"tls"`
+	KeepAlive    time.Duration   `json:"keepAlive"`
+	CleanSession *bool           `json:"cleanSession"`
+	LastWill     *LastWillConfig `json:"lastWill"`
+}
+
+// PushoverConfig configures the Pushover notification backend.
+type PushoverConfig struct {
+	User  string `json:"user"`
+	Token string `json:"token"`
+}
+
+// TelegramConfig configures the Telegram bot API notification backend.
+type TelegramConfig struct {
+	BotToken string `json:"botToken"`
+	ChatID   string `json:"chatId"`
+}
+
+// SMTPConfig configures the email notification backend.
+type SMTPConfig struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
+
+// WebhookConfig configures the generic HTTP webhook notification backend.
+// BodyTemplate is a text/template rendered with an Event as its data. Since
+// the body is typically JSON, use the template's {{json .Field}} func
+// rather than splicing fields in raw, so values are properly escaped.
+type WebhookConfig struct {
+	URL          string            `json:"url"`
+	Method       string            `json:"method"`
+	Headers      map[string]string `json:"headers"`
+	BodyTemplate string            `json:"bodyTemplate"`
+}
+
+// NotifiersConfig enables and configures the notification backends. Any
+// number of them may be set at once; an Event is fanned out to all of
+// them. DedupeWindow suppresses repeating the same message for the same
+// pump within that duration.
+type NotifiersConfig struct {
+	Pushover     *PushoverConfig `json:"pushover"`
+	Telegram     *TelegramConfig `json:"telegram"`
+	SMTP         *SMTPConfig     `json:"smtp"`
+	Webhook      *WebhookConfig  `json:"webhook"`
+	DedupeWindow time.Duration   `json:"dedupeWindow"`
+}
+
+// Config is the top-level layout of the pump-autoswitch config file.
+type Config struct {
+	MQTT      MQTTConfig      `json:"mqtt"`
+	Notifiers NotifiersConfig `json:"notifiers"`
+	Pumps     []PumpConfig    `json:"pumps"`
+
+	// EventQueueSize bounds how many incoming MQTT messages may be queued
+	// for processing before the oldest one is dropped.
+	EventQueueSize int `json:"eventQueueSize"`
+}
+
+func loadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	var cfg Config
+	if err := json.NewDecoder(f).Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+
+	if len(cfg.Pumps) == 0 {
+		return nil, fmt.Errorf("config must define at least one pump")
+	}
+
+	if err := validatePumpIDs(cfg.Pumps); err != nil {
+		return nil, err
+	}
+
+	applyMQTTDefaults(&cfg.MQTT)
+
+	if err := deriveLastWill(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.EventQueueSize < 0 {
+		return nil, fmt.Errorf("eventQueueSize must not be negative")
+	}
+
+	if cfg.EventQueueSize == 0 {
+		cfg.EventQueueSize = 256
+	}
+
+	for i := range cfg.Pumps {
+		applyPumpDefaults(&cfg.Pumps[i])
+
+		if err := validateSafetyConfig(cfg.Pumps[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cfg, nil
+}
+
+// validateSafetyConfig rejects a pump that sets MaxRunTime without also
+// setting MinOffTime. Without a cooldown, the forced-off transition at the
+// max-run cutoff is immediately undone by the next tick (the station that
+// caused it is usually still active), so the pump resumes running almost
+// continuously instead of being held off — defeating the point of the
+// cutoff.
+func validateSafetyConfig(p PumpConfig) error {
+	if p.Safety.MaxRunTime > 0 && p.Safety.MinOffTime == 0 {
+		return fmt.Errorf("pump %q: safety.minOffTime must be set when safety.maxRunTime is set, otherwise the max-run cutoff is immediately undone", p.ID)
+	}
+
+	return nil
+}
+
+// validatePumpIDs rejects a blank or duplicate PumpConfig.ID. IDs key every
+// per-pump Prometheus metric and the Home Assistant discovery topic and
+// unique_id, so two pumps colliding on ID would silently merge into one in
+// both places.
+func validatePumpIDs(pumps []PumpConfig) error {
+	seen := make(map[string]bool, len(pumps))
+
+	for _, p := range pumps {
+		if p.ID == "" {
+			return fmt.Errorf("every pump must have a non-empty id")
+		}
+
+		if seen[p.ID] {
+			return fmt.Errorf("duplicate pump id %q", p.ID)
+		}
+
+		seen[p.ID] = true
+	}
+
+	return nil
+}
+
+// deriveLastWill ties the broker-level LastWill to a pump's
+// AvailabilityTopic, so an unclean disconnect flips that pump's Home
+// Assistant availability to offline on its own, without waiting for an
+// explicit publishAvailability(false) call that may never arrive.
+//
+// paho only supports a single LWT per connection, so this can only cover
+// one pump. If exactly one pump declares an AvailabilityTopic and
+// mqtt.lastWill isn't set explicitly, it is derived from that pump. If more
+// than one pump declares an AvailabilityTopic, the caller must set
+// mqtt.lastWill explicitly and accept that only one pump's availability is
+// broker-enforced; the rest still get it best-effort via
+// publishAvailability on connect and shutdown.
+func deriveLastWill(cfg *Config) error {
+	if cfg.MQTT.LastWill != nil {
+		return nil
+	}
+
+	var withAvailability []PumpConfig
+	for _, p := range cfg.Pumps {
+		if p.AvailabilityTopic != "" {
+			withAvailability = append(withAvailability, p)
+		}
+	}
+
+	switch len(withAvailability) {
+	case 0:
+		return nil
+	case 1:
+		pump := withAvailability[0]
+		cfg.MQTT.LastWill = &LastWillConfig{
+			Topic:   pump.AvailabilityTopic,
+			Payload: "offline",
+			QoS:     pump.QoS,
+			Retain:  true,
+		}
+		return nil
+	default:
+		return fmt.Errorf("mqtt.lastWill must be set explicitly when more than one pump defines an availabilityTopic, since the broker only supports a single last will")
+	}
+}
+
+func applyMQTTDefaults(m *MQTTConfig) {
+	if m.ClientID == "" {
+		m.ClientID = "pump-autoswitch"
+	}
+
+	if m.KeepAlive == 0 {
+		m.KeepAlive = 30 * time.Second
+	}
+}
+
+func applyPumpDefaults(p *PumpConfig) {
+	if p.OnPayload == "" {
+		p.OnPayload = "on"
+	}
+
+	if p.OffPayload == "" {
+		p.OffPayload = "off"
+	}
+
+	if p.IdleTimeout == 0 {
+		p.IdleTimeout = 5 * time.Second
+	}
+
+	if p.Name == "" {
+		p.Name = p.ID
+	}
+
+	if p.DiscoveryPrefix == "" {
+		p.DiscoveryPrefix = "homeassistant"
+	}
+
+	for i := range p.Stations {
+		if p.Stations[i].StatePath == "" {
+			p.Stations[i].StatePath = "state"
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+)
+
+// eventQueue is a bounded ring buffer of pending MQTT messages, fed by the
+// client's publish handler. Pushing never blocks: once the buffer is full
+// the oldest message is dropped rather than stalling the MQTT client's
+// callback goroutine, which would otherwise stall keepalives and cause
+// disconnects.
+type eventQueue struct {
+	mu       sync.Mutex
+	buf      []MQTT.Message
+	capacity int
+	wake     chan struct{}
+}
+
+func newEventQueue(capacity int) *eventQueue {
+	return &eventQueue{capacity: capacity, wake: make(chan struct{}, 1)}
+}
+
+func (q *eventQueue) push(msg MQTT.Message) {
+	q.mu.Lock()
+	if len(q.buf) >= q.capacity {
+		dropped := q.buf[0]
+		q.buf = q.buf[1:]
+		droppedMessagesTotal.WithLabelValues(dropped.Topic()).Inc()
+		slog.Warn("event queue full, dropping oldest message", slog.String("topic", dropped.Topic()))
+	}
+
+	q.buf = append(q.buf, msg)
+	q.mu.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// drain removes and returns every message currently queued.
+func (q *eventQueue) drain() []MQTT.Message {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	items := q.buf
+	q.buf = nil
+	return items
+}
+
+// coalesceMessages drops messages that are immediately superseded by a
+// later message on the same topic, keeping only the most recent payload
+// per topic while preserving the relative order of the survivors. This
+// avoids redundant ticker resets in the pump switcher when a station
+// reports the same transition multiple times in a row.
+func coalesceMessages(msgs []MQTT.Message) []MQTT.Message {
+	latest := make(map[string]int, len(msgs))
+	for i, msg := range msgs {
+		latest[msg.Topic()] = i
+	}
+
+	out := make([]MQTT.Message, 0, len(latest))
+	for i, msg := range msgs {
+		if latest[msg.Topic()] == i {
+			out = append(out, msg)
+		}
+	}
+
+	return out
+}
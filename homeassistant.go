@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+)
+
+// haSwitchConfig is the retained payload published to
+// <prefix>/switch/<id>/config so Home Assistant's MQTT discovery picks up
+// a pump as a switch entity.
+type haSwitchConfig struct {
+	Name                string `json:"name"`
+	UniqueID            string `json:"unique_id"`
+	CommandTopic        string `json:"command_topic"`
+	StateTopic          string `json:"state_topic,omitempty"`
+	AvailabilityTopic   string `json:"availability_topic,omitempty"`
+	PayloadOn           string `json:"payload_on"`
+	PayloadOff          string `json:"payload_off"`
+	PayloadAvailable    string `json:"payload_available,omitempty"`
+	PayloadNotAvailable string `json:"payload_not_available,omitempty"`
+}
+
+// publishDiscovery announces cfg as a Home Assistant switch entity, if
+// discovery is enabled for it.
+func publishDiscovery(client MQTT.Client, cfg PumpConfig) error {
+	if !cfg.Discovery {
+		return nil
+	}
+
+	payload := haSwitchConfig{
+		Name:                cfg.Name,
+		UniqueID:            cfg.ID,
+		CommandTopic:        cfg.CommandTopic,
+		StateTopic:          cfg.StateTopic,
+		AvailabilityTopic:   cfg.AvailabilityTopic,
+		PayloadOn:           cfg.OnPayload,
+		PayloadOff:          cfg.OffPayload,
+		PayloadAvailable:    "online",
+		PayloadNotAvailable: "offline",
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal discovery config: %w", err)
+	}
+
+	topic := fmt.Sprintf("%s/switch/%s/config", cfg.DiscoveryPrefix, cfg.ID)
+	token := client.Publish(topic, byte(1), true, body)
+	if token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to publish discovery config: %w", token.Error())
+	}
+
+	slog.Info("published home assistant discovery config", slog.String("pump", cfg.ID), slog.String("topic", topic))
+	return nil
+}
+
+// publishState reports the pump's current on/off state to its StateTopic,
+// if one is configured.
+func publishState(client MQTT.Client, cfg PumpConfig, active bool) {
+	if cfg.StateTopic == "" {
+		return
+	}
+
+	payload := cfg.OffPayload
+	if active {
+		payload = cfg.OnPayload
+	}
+
+	token := client.Publish(cfg.StateTopic, cfg.QoS, true, payload)
+	if token.Wait() && token.Error() != nil {
+		slog.Error("failed to publish pump state", slog.String("pump", cfg.ID), slog.Any("error", token.Error()))
+	}
+}
+
+// publishAvailability reports whether the pump is reachable, if an
+// AvailabilityTopic is configured.
+func publishAvailability(client MQTT.Client, cfg PumpConfig, online bool) {
+	if cfg.AvailabilityTopic == "" {
+		return
+	}
+
+	payload := "offline"
+	if online {
+		payload = "online"
+	}
+
+	token := client.Publish(cfg.AvailabilityTopic, cfg.QoS, true, payload)
+	if token.Wait() && token.Error() != nil {
+		slog.Error("failed to publish pump availability", slog.String("pump", cfg.ID), slog.Any("error", token.Error()))
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// extractState reads the value at the dot-separated path (e.g. "state" or
+// "relays.0.ison") out of a JSON payload and reports whether it represents
+// an "active" state. Numbers are active when non-zero, bools are used
+// as-is, and strings are active when they equal "1", "true" or "on"
+// (case-insensitive).
+func extractState(payload []byte, path string) (bool, error) {
+	var data interface{}
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return false, fmt.Errorf("failed to parse payload: %w", err)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch v := data.(type) {
+		case map[string]interface{}:
+			val, ok := v[key]
+			if !ok {
+				return false, fmt.Errorf("path element %q not found", key)
+			}
+			data = val
+
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return false, fmt.Errorf("path element %q is not a valid index", key)
+			}
+			data = v[idx]
+
+		default:
+			return false, fmt.Errorf("path element %q has no children in payload", key)
+		}
+	}
+
+	switch v := data.(type) {
+	case bool:
+		return v, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		switch strings.ToLower(v) {
+		case "1", "true", "on":
+			return true, nil
+		default:
+			return false, nil
+		}
+	default:
+		return false, fmt.Errorf("unsupported value type %T at %q", data, path)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import "testing"
+
+func TestExtractState(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		path    string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "bool true",
+			payload: `{"state": true}`,
+			path:    "state",
+			want:    true,
+		},
+		{
+			name:    "bool false",
+			payload: `{"state": false}`,
+			path:    "state",
+			want:    false,
+		},
+		{
+			name:    "non-zero float is active",
+			payload: `{"state": 1.5}`,
+			path:    "state",
+			want:    true,
+		},
+		{
+			name:    "zero float is inactive",
+			payload: `{"state": 0}`,
+			path:    "state",
+			want:    false,
+		},
+		{
+			name:    "negative float is active",
+			payload: `{"state": -1}`,
+			path:    "state",
+			want:    true,
+		},
+		{
+			name:    "string on",
+			payload: `{"state": "ON"}`,
+			path:    "state",
+			want:    true,
+		},
+		{
+			name:    "string true",
+			payload: `{"state": "true"}`,
+			path:    "state",
+			want:    true,
+		},
+		{
+			name:    "string one",
+			payload: `{"state": "1"}`,
+			path:    "state",
+			want:    true,
+		},
+		{
+			name:    "unrecognised string is inactive",
+			payload: `{"state": "standby"}`,
+			path:    "state",
+			want:    false,
+		},
+		{
+			name:    "array index",
+			payload: `{"relays": [{"ison": true}, {"ison": false}]}`,
+			path:    "relays.1.ison",
+			want:    false,
+		},
+		{
+			name:    "nested object path",
+			payload: `{"a": {"b": {"c": true}}}`,
+			path:    "a.b.c",
+			want:    true,
+		},
+		{
+			name:    "missing path segment",
+			payload: `{"state": true}`,
+			path:    "missing",
+			wantErr: true,
+		},
+		{
+			name:    "index out of range",
+			payload: `{"relays": [true]}`,
+			path:    "relays.5",
+			wantErr: true,
+		},
+		{
+			name:    "index into non-array",
+			payload: `{"state": true}`,
+			path:    "state.0",
+			wantErr: true,
+		},
+		{
+			name:    "unsupported value type",
+			payload: `{"state": null}`,
+			path:    "state",
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			payload: `not json`,
+			path:    "state",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractState([]byte(tt.payload), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractState() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("extractState() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
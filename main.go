@@ -1,34 +1,21 @@
 package main
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"flag"
-	"fmt"
-	"io"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/signal"
-	"strings"
-	"time"
 
 	MQTT "github.com/eclipse/paho.mqtt.golang"
 )
 
 var (
-	logLevel      = flag.String("logLevel", "INFO", "DEBUG, INFO, WARN, ERROR")
-	broker        = flag.String("broker", "tcp://sarah.fritz.box:1883", "MQTT broker URL")
-	pushoverUser  = flag.String("pushoverUser", "", "User for Pushover notifications")
-	pushoverToken = flag.String("pushoverToken", "", "Token for Pushover notifications")
+	logLevel   = flag.String("logLevel", "INFO", "DEBUG, INFO, WARN, ERROR")
+	configPath = flag.String("config", "pump-autoswitch.json", "Path to the config file")
+	listen     = flag.String("listen", ":9090", "Address to serve /metrics, /healthz and /readyz on")
 )
 
-type StationState struct {
-	station string
-	state   bool
-}
-
 func main() {
 	flag.Parse()
 	lvl := &slog.LevelVar{}
@@ -37,176 +24,126 @@ func main() {
 		Level: lvl,
 	})))
 
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		slog.Error("failed to load config", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	serveMetrics(*listen)
+
 	ctx, _ := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
 	messages := make(chan MQTT.Message)
+	queue := newEventQueue(cfg.EventQueueSize)
 
-	opts := MQTT.NewClientOptions()
-	opts.AddBroker(*broker)
-	opts.SetClientID("pump-autoswitch")
-	opts.SetDefaultPublishHandler(func(client MQTT.Client, msg MQTT.Message) { messages <- msg })
-	opts.OnConnect = func(c MQTT.Client) { slog.Info("mqtt client connected") }
-	opts.OnConnectionLost = func(c MQTT.Client, err error) { slog.Error("mqtt connection lost", slog.Any("error", err)) }
-	opts.OnReconnecting = func(c MQTT.Client, co *MQTT.ClientOptions) { slog.Info("mqtt client reconnecting") }
+	// Route incoming station topics to every pump switcher that subscribes
+	// to them, since the same station can feed more than one pump.
+	routes := make(map[string][]stationRoute)
+	topics := make(map[string]byte)
 
-	client := MQTT.NewClient(opts)
-	if token := client.Connect(); token.Wait() && token.Error() != nil {
-		panic(token.Error())
-	}
+	var client MQTT.Client
 
-	switcher := newPumpSwitcher(ctx, client)
-	stations := map[string]byte{
-		"opensprinkler/station/0": byte(1),
-		"opensprinkler/station/1": byte(1),
-		"opensprinkler/station/2": byte(1),
-		"opensprinkler/station/3": byte(1),
-		"opensprinkler/station/4": byte(1),
-		"opensprinkler/station/5": byte(1),
-		"opensprinkler/station/6": byte(1),
-		"opensprinkler/station/7": byte(1),
-		"shellies/pump/relay/0":   byte(1), // for debugging purposes
-	}
+	onConnect := func(c MQTT.Client) {
+		slog.Info("mqtt client connected")
+		mqttConnected.Set(1)
+		mqttReady.Store(true)
 
-	if token := client.SubscribeMultiple(stations, nil); token.Wait() && token.Error() != nil {
-		panic(token.Error())
+		if token := c.SubscribeMultiple(topics, nil); token.Wait() && token.Error() != nil {
+			slog.Error("failed to subscribe", slog.Any("error", token.Error()))
+		}
+
+		for _, pumpCfg := range cfg.Pumps {
+			if err := publishDiscovery(c, pumpCfg); err != nil {
+				slog.Error("failed to publish discovery", slog.String("pump", pumpCfg.ID), slog.Any("error", err))
+			}
+
+			publishAvailability(c, pumpCfg, true)
+		}
 	}
 
-loop:
-	for {
-		select {
-		case msg := <-messages:
-			slog.Debug("mqtt message incomming", slog.String("topic", msg.Topic()), slog.String("payload", string(msg.Payload())))
+	opts, err := newClientOptions(cfg.MQTT, onConnect)
+	if err != nil {
+		slog.Error("failed to build mqtt client options", slog.Any("error", err))
+		os.Exit(1)
+	}
 
-			if strings.HasPrefix(msg.Topic(), "opensprinkler/station") {
-				var payload struct {
-					State int `json:"state"`
-				}
+	opts.SetDefaultPublishHandler(func(client MQTT.Client, msg MQTT.Message) {
+		messagesReceivedTotal.WithLabelValues(msg.Topic()).Inc()
+		queue.push(msg)
+	})
+	opts.OnConnectionLost = func(c MQTT.Client, err error) {
+		slog.Error("mqtt connection lost", slog.Any("error", err))
+		mqttConnected.Set(0)
+		mqttReady.Store(false)
+	}
+	opts.OnReconnecting = func(c MQTT.Client, co *MQTT.ClientOptions) {
+		slog.Info("mqtt client reconnecting")
+		mqttReconnectsTotal.Inc()
+	}
 
-				err := json.NewDecoder(bytes.NewReader(msg.Payload())).Decode(&payload)
-				if err != nil {
-					slog.Error("failed to parse message", slog.Any("error", err))
-					continue
-				}
+	client = MQTT.NewClient(opts)
 
-				switcher <- StationState{msg.Topic(), payload.State == 1}
-			}
+	notifier := newDispatcher(buildNotifiers(cfg.Notifiers), cfg.Notifiers.DedupeWindow)
 
-			msg.Ack()
+	for _, pumpCfg := range cfg.Pumps {
+		switcher := newPumpSwitcher(ctx, client, pumpCfg, notifier)
 
-		case <-ctx.Done():
-			break loop
+		for _, stationCfg := range pumpCfg.Stations {
+			routes[stationCfg.Topic] = append(routes[stationCfg.Topic], stationRoute{stationCfg, switcher})
+			topics[stationCfg.Topic] = byte(1)
 		}
 	}
-}
 
-func newPumpSwitcher(ctx context.Context, client MQTT.Client) chan<- StationState {
-	stationStates := make(map[string]bool)
-	states := make(chan StationState, 10)
-
-	duration := 5 * time.Second
-	ticker := time.NewTicker(duration)
+	if token := client.Connect(); token.Wait() && token.Error() != nil {
+		panic(token.Error())
+	}
 
+	// Accumulate pushes off the MQTT callback goroutine and hand them to the
+	// main loop in batches, so a slow subscriber never blocks the callback
+	// (and thus keepalives) the way a direct channel send would.
 	go func() {
-		isPumpActive := false
 		for {
 			select {
-			case state := <-states:
-				slog.Debug("station state updated", slog.String("station", state.station), slog.Bool("state", state.state))
-
-				stationStates[state.station] = state.state
-				ticker.Reset(duration)
-
-				if !state.state {
-					continue
+			case <-queue.wake:
+				for _, msg := range coalesceMessages(queue.drain()) {
+					select {
+					case messages <- msg:
+					case <-ctx.Done():
+						return
+					}
 				}
-
-			case <-ticker.C:
-				break
-
 			case <-ctx.Done():
-				switchPump(client, false)
-				close(states)
 				return
 			}
+		}
+	}()
 
-			p := false
-			for _, s := range stationStates {
-				p = p || s
-			}
+loop:
+	for {
+		select {
+		case msg := <-messages:
+			slog.Debug("mqtt message incomming", slog.String("topic", msg.Topic()), slog.String("payload", string(msg.Payload())))
 
-			if p == isPumpActive {
-				continue
-			}
+			for _, route := range routes[msg.Topic()] {
+				state, err := extractState(msg.Payload(), route.station.StatePath)
+				if err != nil {
+					slog.Error("failed to parse message", slog.Any("error", err))
+					continue
+				}
 
-			err := switchPump(client, p)
-			if err != nil {
-				notify("Failed to switch pump!")
-				continue
+				stationActive.WithLabelValues(msg.Topic()).Set(boolToFloat(state))
+				route.switcher <- StationState{msg.Topic(), state}
 			}
 
-			message := "Pump turned off"
-			if p {
-				message = "Pump turned on"
-			}
+			msg.Ack()
 
-			notify(message)
-			isPumpActive = p
+		case <-ctx.Done():
+			break loop
 		}
-	}()
-
-	return states
-}
-
-func switchPump(client MQTT.Client, active bool) error {
-	payload := "off"
-	if active {
-		payload = "on"
 	}
-
-	slog.Debug("switching pump", slog.String("payload", payload))
-
-	token := client.Publish("shellies/pump/relay/0/command", byte(1), false, payload)
-	if token.Wait() != true {
-		slog.Error("failed to publish message", slog.Any("error", token.Error()))
-	}
-
-	return token.Error()
 }
 
-func notify(message string) error {
-	var payload = struct {
-		Token   string `json:"token"`
-		User    string `json:"user"`
-		Message string `json:"message"`
-	}{
-		Token:   *pushoverToken,
-		User:    *pushoverUser,
-		Message: message,
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		slog.Error("failed to marshal pushover message", slog.Any("message", payload.Message), slog.Any("error", err))
-		return err
-	}
-
-	resp, err := http.Post("https://api.pushover.net/1/messages.json", "application/json", bytes.NewReader(body))
-	if err != nil {
-		slog.Error("failed to post pushover message", slog.Any("message", payload.Message), slog.Any("error", err))
-		return err
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		respBody, err := io.ReadAll(resp.Body)
-		slog.Error("failed to post pushover message",
-			slog.Any("message", payload.Message),
-			slog.Int("code", resp.StatusCode),
-			slog.String("response", string(respBody)),
-			slog.Any("error", err),
-		)
-
-		return fmt.Errorf(resp.Status)
-	}
-
-	slog.Info("pushover notification successful", slog.String("message", payload.Message))
-	return nil
+type stationRoute struct {
+	station  StationConfig
+	switcher chan<- StationState
 }
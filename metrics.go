@@ -0,0 +1,62 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	mqttConnected = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pump_autoswitch_mqtt_connected",
+		Help: "Whether the MQTT client is currently connected (1) or not (0).",
+	})
+
+	mqttReconnectsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pump_autoswitch_mqtt_reconnects_total",
+		Help: "Number of times the MQTT client has started reconnecting.",
+	})
+
+	messagesReceivedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pump_autoswitch_messages_received_total",
+		Help: "Number of MQTT messages received, by topic.",
+	}, []string{"topic"})
+
+	droppedMessagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pump_autoswitch_dropped_messages_total",
+		Help: "Number of queued MQTT messages dropped because the event queue was full, by topic.",
+	}, []string{"topic"})
+
+	stationActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pump_autoswitch_station_active",
+		Help: "Whether a station is currently reported active (1) or not (0).",
+	}, []string{"station"})
+
+	pumpActive = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "pump_autoswitch_pump_active",
+		Help: "Whether a pump is currently switched on (1) or off (0).",
+	}, []string{"pump"})
+
+	pumpSwitchTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pump_autoswitch_pump_switch_total",
+		Help: "Number of pump switch attempts, by pump and result.",
+	}, []string{"pump", "result"})
+
+	notificationTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pump_autoswitch_notification_total",
+		Help: "Number of notifications sent, by backend and result.",
+	}, []string{"backend", "result"})
+
+	pumpOnDurationSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pump_autoswitch_pump_on_duration_seconds",
+		Help:    "Duration a pump stayed switched on before being switched off again.",
+		Buckets: prometheus.ExponentialBuckets(10, 2, 12),
+	}, []string{"pump"})
+)
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+)
+
+// newClientOptions builds the paho client options for cfg, wiring up
+// authentication, TLS, the last will and the reconnect behaviour needed to
+// survive a broker restart. onConnect is called every time a connection is
+// (re-)established, including after a reconnect, so callers can
+// re-subscribe there.
+func newClientOptions(cfg MQTTConfig, onConnect MQTT.OnConnectHandler) (*MQTT.ClientOptions, error) {
+	opts := MQTT.NewClientOptions()
+	opts.AddBroker(cfg.Broker)
+	opts.SetClientID(cfg.ClientID)
+	opts.SetUsername(cfg.Username)
+	opts.SetPassword(cfg.Password)
+	opts.SetKeepAlive(cfg.KeepAlive)
+	opts.SetCleanSession(cfg.CleanSession == nil || *cfg.CleanSession)
+
+	opts.SetAutoReconnect(true)
+	opts.SetConnectRetry(true)
+
+	if cfg.TLS != nil {
+		tlsConfig, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build tls config: %w", err)
+		}
+
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	if cfg.LastWill != nil {
+		opts.SetWill(cfg.LastWill.Topic, cfg.LastWill.Payload, cfg.LastWill.QoS, cfg.LastWill.Retain)
+	}
+
+	opts.OnConnect = onConnect
+
+	return opts, nil
+}
+
+func newTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse ca file %q", cfg.CAFile)
+		}
+
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" && cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Event carries the structured context for a single notification: which
+// pump it concerns, which stations are driving it, and when it happened.
+// Backends format Message however suits their medium.
+type Event struct {
+	PumpID    string
+	Stations  []string
+	Message   string
+	Timestamp time.Time
+}
+
+// Notifier delivers an Event through some external channel, e.g. a push
+// notification service, a chat bot or email.
+type Notifier interface {
+	Name() string
+	Notify(event Event) error
+}
+
+// dispatcher fans an Event out to every configured Notifier, suppressing
+// repeats of the same message for the same pump within dedupeWindow.
+type dispatcher struct {
+	notifiers    []Notifier
+	dedupeWindow time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func newDispatcher(notifiers []Notifier, dedupeWindow time.Duration) *dispatcher {
+	return &dispatcher{
+		notifiers:    notifiers,
+		dedupeWindow: dedupeWindow,
+		last:         make(map[string]time.Time),
+	}
+}
+
+func (d *dispatcher) notify(event Event) {
+	key := event.PumpID + "|" + event.Message
+
+	d.mu.Lock()
+	if last, ok := d.last[key]; ok && d.dedupeWindow > 0 && event.Timestamp.Sub(last) < d.dedupeWindow {
+		d.mu.Unlock()
+		slog.Debug("suppressing duplicate notification", slog.String("pump", event.PumpID), slog.String("message", event.Message))
+		return
+	}
+	d.last[key] = event.Timestamp
+	d.mu.Unlock()
+
+	for _, n := range d.notifiers {
+		if err := n.Notify(event); err != nil {
+			slog.Error("failed to send notification", slog.String("backend", n.Name()), slog.Any("error", err))
+			notificationTotal.WithLabelValues(n.Name(), "failure").Inc()
+			continue
+		}
+
+		notificationTotal.WithLabelValues(n.Name(), "success").Inc()
+	}
+}
+
+// buildNotifiers constructs a Notifier for every backend enabled in cfg.
+func buildNotifiers(cfg NotifiersConfig) []Notifier {
+	var notifiers []Notifier
+
+	if cfg.Pushover != nil {
+		notifiers = append(notifiers, &PushoverNotifier{cfg: *cfg.Pushover})
+	}
+
+	if cfg.Telegram != nil {
+		notifiers = append(notifiers, &TelegramNotifier{cfg: *cfg.Telegram})
+	}
+
+	if cfg.SMTP != nil {
+		notifiers = append(notifiers, &SMTPNotifier{cfg: *cfg.SMTP})
+	}
+
+	if cfg.Webhook != nil {
+		webhook, err := newWebhookNotifier(*cfg.Webhook)
+		if err != nil {
+			slog.Error("failed to configure webhook notifier", slog.Any("error", err))
+		} else {
+			notifiers = append(notifiers, webhook)
+		}
+	}
+
+	return notifiers
+}
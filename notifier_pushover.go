@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PushoverNotifier delivers events via the Pushover API.
+type PushoverNotifier struct {
+	cfg PushoverConfig
+}
+
+func (n *PushoverNotifier) Name() string { return "pushover" }
+
+func (n *PushoverNotifier) Notify(event Event) error {
+	payload := struct {
+		Token   string `json:"token"`
+		User    string `json:"user"`
+		Message string `json:"message"`
+	}{
+		Token:   n.cfg.Token,
+		User:    n.cfg.User,
+		Message: event.Message,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal pushover message: %w", err)
+	}
+
+	resp, err := http.Post("https://api.pushover.net/1/messages.json", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post pushover message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("pushover request failed: %s: %s", resp.Status, respBody)
+	}
+
+	return nil
+}
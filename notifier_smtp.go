@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPNotifier delivers events as plain-text email.
+type SMTPNotifier struct {
+	cfg SMTPConfig
+}
+
+func (n *SMTPNotifier) Name() string { return "smtp" }
+
+func (n *SMTPNotifier) Notify(event Event) error {
+	addr := fmt.Sprintf("%s:%d", n.cfg.Host, n.cfg.Port)
+
+	var auth smtp.Auth
+	if n.cfg.Username != "" {
+		auth = smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+	}
+
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: pump-autoswitch: %s\r\n\r\n%s\r\n",
+		n.cfg.From, strings.Join(n.cfg.To, ", "), event.PumpID, event.Message)
+
+	if err := smtp.SendMail(addr, auth, n.cfg.From, n.cfg.To, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// TelegramNotifier delivers events via a Telegram bot.
+type TelegramNotifier struct {
+	cfg TelegramConfig
+}
+
+func (n *TelegramNotifier) Name() string { return "telegram" }
+
+func (n *TelegramNotifier) Notify(event Event) error {
+	payload := struct {
+		ChatID string `json:"chat_id"`
+		Text   string `json:"text"`
+	}{
+		ChatID: n.cfg.ChatID,
+		Text:   event.Message,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telegram message: %w", err)
+	}
+
+	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", n.cfg.BotToken)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post telegram message: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("telegram request failed: %s: %s", resp.Status, respBody)
+	}
+
+	return nil
+}
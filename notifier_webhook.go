@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+)
+
+// webhookFuncs are available inside BodyTemplate. json marshals its
+// argument through encoding/json so a field can be safely embedded in a
+// JSON body template (e.g. {{json .Message}}) without the caller having to
+// hand-escape quotes themselves.
+var webhookFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal value for webhook template: %w", err)
+		}
+
+		return string(b), nil
+	},
+}
+
+// WebhookNotifier delivers events as an HTTP request with a templated JSON
+// body, for integrations with no dedicated backend.
+type WebhookNotifier struct {
+	cfg    WebhookConfig
+	tmpl   *template.Template
+	client *http.Client
+}
+
+func newWebhookNotifier(cfg WebhookConfig) (*WebhookNotifier, error) {
+	tmpl, err := template.New("webhook").Funcs(webhookFuncs).Parse(cfg.BodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse webhook body template: %w", err)
+	}
+
+	return &WebhookNotifier{cfg: cfg, tmpl: tmpl, client: http.DefaultClient}, nil
+}
+
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+func (n *WebhookNotifier) Notify(event Event) error {
+	var body bytes.Buffer
+	if err := n.tmpl.Execute(&body, event); err != nil {
+		return fmt.Errorf("failed to render webhook body: %w", err)
+	}
+
+	method := n.cfg.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	req, err := http.NewRequest(method, n.cfg.URL, &body)
+	if err != nil {
+		return fmt.Errorf("failed to build webhook request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range n.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook request failed: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	MQTT "github.com/eclipse/paho.mqtt.golang"
+)
+
+// StationState is a single station's reported activation state, identified
+// by the MQTT topic it was received on.
+type StationState struct {
+	station string
+	state   bool
+}
+
+// newPumpSwitcher starts a goroutine that tracks the stations feeding a
+// single pump and switches that pump on or off whenever the combined
+// station state changes. It returns the channel station updates should be
+// sent on.
+func newPumpSwitcher(ctx context.Context, client MQTT.Client, cfg PumpConfig, notifier *dispatcher) chan<- StationState {
+	stationStates := make(map[string]bool)
+	states := make(chan StationState, 10)
+
+	ticker := time.NewTicker(cfg.IdleTimeout)
+
+	go func() {
+		isPumpActive := false
+		var onSince time.Time
+		var lastOffTime time.Time
+
+		var maxRunTimer *time.Timer
+		var maxRunC <-chan time.Time
+
+		// cooldownReported and scheduleReported track whether the
+		// corresponding guard has already notified for the current
+		// suppression episode, so a station left active through an entire
+		// cooldown or blackout window only produces one notification
+		// instead of one per ticker tick.
+		cooldownReported := false
+		scheduleReported := false
+
+		// transition switches the pump to p and reports the outcome,
+		// including the safety-guard bookkeeping (cooldown timestamp and
+		// max-run-time watchdog) that depends on the new state.
+		transition := func(p bool, message string) {
+			err := switchPump(client, cfg, p)
+			if err != nil {
+				pumpSwitchTotal.WithLabelValues(cfg.ID, "failure").Inc()
+				notifier.notify(Event{
+					PumpID:    cfg.ID,
+					Stations:  activeStations(stationStates),
+					Message:   fmt.Sprintf("Failed to switch pump %q!", cfg.ID),
+					Timestamp: time.Now(),
+				})
+				return
+			}
+
+			pumpSwitchTotal.WithLabelValues(cfg.ID, "success").Inc()
+			publishState(client, cfg, p)
+			pumpActive.WithLabelValues(cfg.ID).Set(boolToFloat(p))
+
+			if p {
+				onSince = time.Now()
+
+				if cfg.Safety.MaxRunTime > 0 {
+					maxRunTimer = time.NewTimer(cfg.Safety.MaxRunTime)
+					maxRunC = maxRunTimer.C
+				}
+			} else {
+				if !onSince.IsZero() {
+					pumpOnDurationSeconds.WithLabelValues(cfg.ID).Observe(time.Since(onSince).Seconds())
+				}
+
+				if maxRunTimer != nil {
+					maxRunTimer.Stop()
+					maxRunTimer = nil
+					maxRunC = nil
+				}
+
+				lastOffTime = time.Now()
+			}
+
+			notifier.notify(Event{
+				PumpID:    cfg.ID,
+				Stations:  activeStations(stationStates),
+				Message:   message,
+				Timestamp: time.Now(),
+			})
+			isPumpActive = p
+		}
+
+		for {
+			select {
+			case state := <-states:
+				slog.Debug("station state updated", slog.String("pump", cfg.ID), slog.String("station", state.station), slog.Bool("state", state.state))
+
+				stationStates[state.station] = state.state
+				ticker.Reset(cfg.IdleTimeout)
+
+				if !state.state {
+					continue
+				}
+
+			case <-ticker.C:
+				break
+
+			case <-maxRunC:
+				slog.Warn("pump exceeded max run time, forcing off", slog.String("pump", cfg.ID), slog.Duration("maxRunTime", cfg.Safety.MaxRunTime))
+				transition(false, fmt.Sprintf("Pump %q forced off: exceeded max run time of %s", cfg.ID, cfg.Safety.MaxRunTime))
+				continue
+
+			case <-ctx.Done():
+				switchPump(client, cfg, false)
+				publishState(client, cfg, false)
+				publishAvailability(client, cfg, false)
+				close(states)
+				return
+			}
+
+			p := false
+			for _, s := range stationStates {
+				p = p || s
+			}
+
+			if !p {
+				// Station no longer wants the pump on, so any cooldown or
+				// schedule suppression episode has ended; the next time it
+				// wants on is a fresh episode and should notify again.
+				cooldownReported = false
+				scheduleReported = false
+			}
+
+			if p == isPumpActive {
+				continue
+			}
+
+			if p {
+				if cfg.Safety.MinOffTime > 0 && !lastOffTime.IsZero() && time.Since(lastOffTime) < cfg.Safety.MinOffTime {
+					if !cooldownReported {
+						slog.Warn("suppressing pump switch, still in cooldown", slog.String("pump", cfg.ID))
+						notifier.notify(Event{
+							PumpID:    cfg.ID,
+							Stations:  activeStations(stationStates),
+							Message:   fmt.Sprintf("Pump %q kept off: cooldown not elapsed yet", cfg.ID),
+							Timestamp: time.Now(),
+						})
+						cooldownReported = true
+					}
+					continue
+				}
+
+				allowed, err := withinAllowedWindow(cfg.Safety.AllowedWindows, time.Now())
+				if err != nil {
+					slog.Error("failed to evaluate allowed schedule window", slog.String("pump", cfg.ID), slog.Any("error", err))
+				} else if !allowed {
+					if !scheduleReported {
+						slog.Warn("suppressing pump switch, outside allowed schedule window", slog.String("pump", cfg.ID))
+						notifier.notify(Event{
+							PumpID:    cfg.ID,
+							Stations:  activeStations(stationStates),
+							Message:   fmt.Sprintf("Pump %q kept off: outside allowed schedule", cfg.ID),
+							Timestamp: time.Now(),
+						})
+						scheduleReported = true
+					}
+					continue
+				}
+			}
+
+			message := fmt.Sprintf("Pump %q turned off", cfg.ID)
+			if p {
+				message = fmt.Sprintf("Pump %q turned on", cfg.ID)
+			}
+
+			transition(p, message)
+		}
+	}()
+
+	return states
+}
+
+func activeStations(states map[string]bool) []string {
+	var active []string
+	for station, state := range states {
+		if state {
+			active = append(active, station)
+		}
+	}
+
+	return active
+}
+
+func switchPump(client MQTT.Client, cfg PumpConfig, active bool) error {
+	payload := cfg.OffPayload
+	if active {
+		payload = cfg.OnPayload
+	}
+
+	slog.Debug("switching pump", slog.String("pump", cfg.ID), slog.String("payload", payload))
+
+	token := client.Publish(cfg.CommandTopic, cfg.QoS, cfg.Retain, payload)
+	if token.Wait() != true {
+		slog.Error("failed to publish message", slog.Any("error", token.Error()))
+	}
+
+	return token.Error()
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// withinAllowedWindow reports whether now falls within one of the
+// configured time-of-day windows. No windows configured means always
+// allowed.
+func withinAllowedWindow(windows []TimeWindow, now time.Time) (bool, error) {
+	if len(windows) == 0 {
+		return true, nil
+	}
+
+	nowMinutes := now.Hour()*60 + now.Minute()
+
+	for _, w := range windows {
+		start, err := time.Parse("15:04", w.Start)
+		if err != nil {
+			return false, fmt.Errorf("invalid window start %q: %w", w.Start, err)
+		}
+
+		end, err := time.Parse("15:04", w.End)
+		if err != nil {
+			return false, fmt.Errorf("invalid window end %q: %w", w.End, err)
+		}
+
+		startMinutes := start.Hour()*60 + start.Minute()
+		endMinutes := end.Hour()*60 + end.Minute()
+
+		if startMinutes <= endMinutes {
+			if nowMinutes >= startMinutes && nowMinutes < endMinutes {
+				return true, nil
+			}
+		} else if nowMinutes >= startMinutes || nowMinutes < endMinutes {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func at(hour, minute int) time.Time {
+	return time.Date(2024, 1, 1, hour, minute, 0, 0, time.UTC)
+}
+
+func TestWithinAllowedWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []TimeWindow
+		now     time.Time
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:    "no windows means always allowed",
+			windows: nil,
+			now:     at(3, 0),
+			want:    true,
+		},
+		{
+			name:    "inside a same-day window",
+			windows: []TimeWindow{{Start: "08:00", End: "18:00"}},
+			now:     at(12, 0),
+			want:    true,
+		},
+		{
+			name:    "before a same-day window",
+			windows: []TimeWindow{{Start: "08:00", End: "18:00"}},
+			now:     at(7, 59),
+			want:    false,
+		},
+		{
+			name:    "at the window start is allowed",
+			windows: []TimeWindow{{Start: "08:00", End: "18:00"}},
+			now:     at(8, 0),
+			want:    true,
+		},
+		{
+			name:    "at the window end is not allowed",
+			windows: []TimeWindow{{Start: "08:00", End: "18:00"}},
+			now:     at(18, 0),
+			want:    false,
+		},
+		{
+			name:    "window spanning midnight, late side",
+			windows: []TimeWindow{{Start: "22:00", End: "06:00"}},
+			now:     at(23, 30),
+			want:    true,
+		},
+		{
+			name:    "window spanning midnight, early side",
+			windows: []TimeWindow{{Start: "22:00", End: "06:00"}},
+			now:     at(1, 0),
+			want:    true,
+		},
+		{
+			name:    "window spanning midnight, outside",
+			windows: []TimeWindow{{Start: "22:00", End: "06:00"}},
+			now:     at(12, 0),
+			want:    false,
+		},
+		{
+			name:    "start equal to end never matches",
+			windows: []TimeWindow{{Start: "10:00", End: "10:00"}},
+			now:     at(10, 0),
+			want:    false,
+		},
+		{
+			name: "matches if any window matches",
+			windows: []TimeWindow{
+				{Start: "08:00", End: "09:00"},
+				{Start: "20:00", End: "21:00"},
+			},
+			now:  at(20, 30),
+			want: true,
+		},
+		{
+			name:    "invalid start",
+			windows: []TimeWindow{{Start: "not-a-time", End: "18:00"}},
+			now:     at(12, 0),
+			wantErr: true,
+		},
+		{
+			name:    "invalid end",
+			windows: []TimeWindow{{Start: "08:00", End: "not-a-time"}},
+			now:     at(12, 0),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withinAllowedWindow(tt.windows, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("withinAllowedWindow() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if got != tt.want {
+				t.Errorf("withinAllowedWindow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}